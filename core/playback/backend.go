@@ -0,0 +1,60 @@
+package playback
+
+import (
+	"context"
+
+	"github.com/navidrome/navidrome/conf"
+)
+
+// BackendEventType identifies what happened on a playback Backend.
+type BackendEventType int
+
+const (
+	// EventEndOfFile fires when the loaded track played through to its end on its own.
+	EventEndOfFile BackendEventType = iota
+	// EventStopped fires when the loaded track ended before finishing on its own,
+	// e.g. because another Load replaced it. Only MpvBackend emits it (for mpv's
+	// "stop" and "quit" end-file reasons); pausing does not emit it.
+	EventStopped
+)
+
+// BackendEvent is published on a Backend's event channel. It is what drives
+// auto-advance, so every Backend implementation shares the same
+// trackSwitcher logic regardless of how it actually plays audio.
+type BackendEvent struct {
+	Type BackendEventType
+}
+
+// Backend abstracts the actual audio output a SpeakerPlaybackDevice drives.
+// This lets the device run against mpv, a pure-Go decoder/output stack, or
+// nothing at all (for tests), without changing any of the queue or
+// auto-advance logic built on top of it.
+type Backend interface {
+	// Load opens path and gets it ready to play, replacing whatever was loaded before.
+	Load(path string) error
+	Play() error
+	Pause() error
+	IsPlaying() bool
+	// Seek moves to offset seconds into the currently loaded track.
+	Seek(offset int) error
+	// Volume sets the output gain, as a float between 0.0 and 1.0.
+	Volume(gain float32) error
+	// Position returns the current playback position, in seconds.
+	Position() int
+	// Events returns the channel BackendEvent values are published on.
+	Events() <-chan BackendEvent
+	Close() error
+}
+
+// newBackend builds the Backend selected by conf.Server.PlaybackBackend
+// ("mpv", the default, "beep" or "null").
+func newBackend(ctx context.Context, deviceName string) (Backend, error) {
+	switch conf.Server.PlaybackBackend {
+	case "beep":
+		return NewBeepBackend(), nil
+	case "null":
+		return NewNullBackend(), nil
+	default:
+		return NewMpvBackend(ctx, deviceName)
+	}
+}
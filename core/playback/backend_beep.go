@@ -0,0 +1,200 @@
+package playback
+
+// BeepBackend plays audio directly through the host's sound device using a
+// pure-Go decode/output stack (github.com/faiface/beep), for deployments
+// where an external mpv binary isn't available (e.g. minimal Docker images).
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/flac"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/speaker"
+	"github.com/faiface/beep/vorbis"
+)
+
+// speakerBufferSize is how much audio beep buffers ahead of the sound device,
+// a tenth of a second as recommended by the beep docs.
+const speakerBufferSize = time.Second / 10
+
+type BeepBackend struct {
+	mu sync.Mutex
+
+	format   beep.Format
+	streamer beep.StreamSeekCloser
+	volume   *effects.Volume
+	ctrl     *beep.Ctrl
+
+	speakerInitialized bool
+	events             chan BackendEvent
+}
+
+func NewBeepBackend() *BeepBackend {
+	return &BeepBackend{events: make(chan BackendEvent, 1)}
+}
+
+func (b *BeepBackend) Load(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	streamer, format, err := decodeBeepStream(path, f)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	if !b.speakerInitialized || format.SampleRate != b.format.SampleRate {
+		if err := speaker.Init(format.SampleRate, format.SampleRate.N(speakerBufferSize)); err != nil {
+			_ = streamer.Close()
+			return fmt.Errorf("initializing speaker: %w", err)
+		}
+		b.speakerInitialized = true
+	}
+
+	// Loading a new track must not change whether playback is paused, to
+	// match mpv's "loadfile replace" behavior, which leaves its independent
+	// pause property untouched. Callers like advanceQueue/Previous/Skip rely
+	// on this to keep playing across a Load without an explicit Play() after
+	// the first one. Only the very first Load defaults to paused, since
+	// Start() is the one caller that issues an explicit Play() afterwards.
+	paused := true
+	if b.ctrl != nil {
+		paused = b.ctrl.Paused
+	}
+
+	if b.streamer != nil {
+		_ = b.streamer.Close()
+	}
+
+	b.format = format
+	b.streamer = streamer
+	b.volume = &effects.Volume{Streamer: streamer, Base: 2}
+	b.ctrl = &beep.Ctrl{Streamer: b.volume, Paused: paused}
+
+	speaker.Clear()
+	speaker.Play(beep.Seq(b.ctrl, beep.Callback(func() {
+		b.events <- BackendEvent{Type: EventEndOfFile}
+	})))
+
+	return nil
+}
+
+// decodeBeepStream picks a decoder based on path's extension. mp3, flac and
+// ogg/vorbis are the formats beep ships decoders for.
+func decodeBeepStream(path string, f *os.File) (beep.StreamSeekCloser, beep.Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return mp3.Decode(f)
+	case ".flac":
+		return flac.Decode(f)
+	case ".ogg":
+		return vorbis.Decode(f)
+	default:
+		_ = f.Close()
+		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s", filepath.Ext(path))
+	}
+}
+
+func (b *BeepBackend) Play() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctrl == nil {
+		return errors.New("no track loaded")
+	}
+
+	speaker.Lock()
+	b.ctrl.Paused = false
+	speaker.Unlock()
+	return nil
+}
+
+func (b *BeepBackend) Pause() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctrl == nil {
+		return errors.New("no track loaded")
+	}
+
+	speaker.Lock()
+	b.ctrl.Paused = true
+	speaker.Unlock()
+	return nil
+}
+
+func (b *BeepBackend) IsPlaying() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.ctrl != nil && !b.ctrl.Paused
+}
+
+func (b *BeepBackend) Seek(offset int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.streamer == nil {
+		return errors.New("no track loaded")
+	}
+
+	speaker.Lock()
+	defer speaker.Unlock()
+	return b.streamer.Seek(b.format.SampleRate.N(time.Duration(offset) * time.Second))
+}
+
+// Volume maps a 0.0-1.0 gain onto beep's logarithmic Volume control.
+func (b *BeepBackend) Volume(gain float32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.volume == nil {
+		return nil
+	}
+
+	speaker.Lock()
+	defer speaker.Unlock()
+	b.volume.Silent = gain <= 0
+	if !b.volume.Silent {
+		b.volume.Volume = math.Log2(float64(gain))
+	}
+	return nil
+}
+
+func (b *BeepBackend) Position() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.streamer == nil {
+		return 0
+	}
+
+	speaker.Lock()
+	defer speaker.Unlock()
+	return int(b.format.SampleRate.D(b.streamer.Position()).Seconds())
+}
+
+func (b *BeepBackend) Events() <-chan BackendEvent {
+	return b.events
+}
+
+func (b *BeepBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	speaker.Close()
+	var err error
+	if b.streamer != nil {
+		err = b.streamer.Close()
+	}
+	close(b.events)
+	return err
+}
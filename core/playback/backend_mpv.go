@@ -0,0 +1,149 @@
+package playback
+
+// MpvBackend drives audio playback through mpv's JSON IPC socket. See mpv.io
+// https://github.com/dexterlb/mpvipc
+// https://mpv.io/manual/master/#json-ipc
+// https://mpv.io/manual/master/#properties
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dexterlb/mpvipc"
+	"github.com/navidrome/navidrome/core/playback/mpv"
+	"github.com/navidrome/navidrome/log"
+)
+
+// timePosPropertyID is the id used to register the "time-pos" property
+// observer with mpv, so property-change events can be told apart from other
+// observed properties on the same connection.
+const timePosPropertyID = 1
+
+type MpvBackend struct {
+	conn *mpvipc.Connection
+
+	events chan BackendEvent
+
+	positionMu sync.Mutex
+	position   int // cached "time-pos", updated as property-change events arrive
+}
+
+// NewMpvBackend starts a new mpv process, which runs until ctx is done, and
+// connects to its control socket.
+func NewMpvBackend(ctx context.Context, deviceName string) (*MpvBackend, error) {
+	conn, err := mpv.OpenMpvAndConnection(ctx, deviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &MpvBackend{
+		conn:   conn,
+		events: make(chan BackendEvent, 1),
+	}
+	go b.listen(ctx)
+	return b, nil
+}
+
+// listen drains mpv's event stream, caching "time-pos" updates and
+// translating "end-file" into BackendEvents, until ctx is done.
+func (b *MpvBackend) listen(ctx context.Context) {
+	events, stop := b.conn.NewEventListener()
+	defer func() { stop <- struct{}{} }()
+	defer close(b.events)
+
+	if _, err := b.conn.Call("observe_property", timePosPropertyID, "time-pos"); err != nil {
+		log.Error("Error observing time-pos property", err)
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			b.handle(event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *MpvBackend) handle(event *mpvipc.Event) {
+	switch event.Name {
+	case "property-change":
+		if event.ID != timePosPropertyID {
+			return
+		}
+		if pos, ok := event.Data.(float64); ok {
+			b.setPosition(int(pos))
+		}
+	case "end-file":
+		switch event.Reason {
+		case "eof":
+			b.events <- BackendEvent{Type: EventEndOfFile}
+		case "stop", "quit":
+			b.events <- BackendEvent{Type: EventStopped}
+		}
+	}
+}
+
+func (b *MpvBackend) setPosition(pos int) {
+	b.positionMu.Lock()
+	defer b.positionMu.Unlock()
+	b.position = pos
+}
+
+func (b *MpvBackend) Load(path string) error {
+	_, err := b.conn.Call("loadfile", path, "replace", 0, "start=10")
+	return err
+}
+
+func (b *MpvBackend) Play() error {
+	return b.conn.Set("pause", false)
+}
+
+func (b *MpvBackend) Pause() error {
+	return b.conn.Set("pause", true)
+}
+
+func (b *MpvBackend) IsPlaying() bool {
+	pausing, err := b.conn.Get("pause")
+	if err != nil {
+		log.Error("Problem getting paused status", err)
+		return false
+	}
+
+	pause, ok := pausing.(bool)
+	if !ok {
+		log.Error("Could not cast pausing to boolean", "value", pausing)
+		return false
+	}
+	return !pause
+}
+
+func (b *MpvBackend) Seek(offset int) error {
+	_, err := b.conn.Call("seek", offset)
+	return err
+}
+
+// Volume sets mpv's volume property, as a percentage derived from a 0.0-1.0 gain.
+func (b *MpvBackend) Volume(gain float32) error {
+	return b.conn.Set("volume", int(gain*100))
+}
+
+// Position returns the last "time-pos" value reported by mpv, as cached by
+// listen's property observer. This avoids round-tripping to mpv (and the
+// retries needed while a track is loading) on every call.
+func (b *MpvBackend) Position() int {
+	b.positionMu.Lock()
+	defer b.positionMu.Unlock()
+	return b.position
+}
+
+func (b *MpvBackend) Events() <-chan BackendEvent {
+	return b.events
+}
+
+func (b *MpvBackend) Close() error {
+	return b.conn.Close()
+}
@@ -0,0 +1,185 @@
+package playback
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dexterlb/mpvipc"
+)
+
+func TestMpvBackend_HandlePropertyChange(t *testing.T) {
+	b := &MpvBackend{events: make(chan BackendEvent, 1)}
+	b.handle(&mpvipc.Event{Name: "property-change", ID: timePosPropertyID, Data: 5.0})
+
+	if got := b.Position(); got != 5 {
+		t.Fatalf("Position() = %d, want 5", got)
+	}
+}
+
+func TestMpvBackend_HandlePropertyChange_IgnoresOtherProperties(t *testing.T) {
+	b := &MpvBackend{events: make(chan BackendEvent, 1)}
+	b.handle(&mpvipc.Event{Name: "property-change", ID: timePosPropertyID + 1, Data: 5.0})
+
+	if got := b.Position(); got != 0 {
+		t.Fatalf("Position() = %d, want 0 for an unrelated property id", got)
+	}
+}
+
+func TestMpvBackend_HandleEndFile(t *testing.T) {
+	cases := []struct {
+		reason    string
+		want      BackendEventType
+		wantEvent bool
+	}{
+		{reason: "eof", want: EventEndOfFile, wantEvent: true},
+		{reason: "stop", want: EventStopped, wantEvent: true},
+		{reason: "quit", want: EventStopped, wantEvent: true},
+		{reason: "error", wantEvent: false},
+	}
+
+	for _, c := range cases {
+		b := &MpvBackend{events: make(chan BackendEvent, 1)}
+		b.handle(&mpvipc.Event{Name: "end-file", Reason: c.reason})
+
+		select {
+		case event := <-b.events:
+			if !c.wantEvent {
+				t.Fatalf("reason %q: got unexpected event %v", c.reason, event)
+			}
+			if event.Type != c.want {
+				t.Fatalf("reason %q: event.Type = %v, want %v", c.reason, event.Type, c.want)
+			}
+		case <-time.After(10 * time.Millisecond):
+			if c.wantEvent {
+				t.Fatalf("reason %q: expected a BackendEvent, got none", c.reason)
+			}
+		}
+	}
+}
+
+// fakeMpvServer is a minimal stand-in for mpv's JSON IPC socket: it
+// acknowledges every command with {"error":"success"} and lets the test push
+// raw event lines, so MpvBackend.listen can be exercised against the real
+// dexterlb/mpvipc client without an actual mpv binary.
+type fakeMpvServer struct {
+	conn net.Conn
+}
+
+// acknowledgeCommands replies "success" to every request, like mpv does for
+// every command we send (loadfile, set_property, observe_property, ...).
+func (s *fakeMpvServer) acknowledgeCommands(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req struct {
+			RequestID int64 `json:"request_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		resp, _ := json.Marshal(map[string]interface{}{
+			"request_id": req.RequestID,
+			"error":      "success",
+		})
+		_, _ = conn.Write(append(resp, '\n'))
+	}
+}
+
+func (s *fakeMpvServer) sendEvent(t *testing.T, event map[string]interface{}) {
+	t.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("could not marshal event: %v", err)
+	}
+	if _, err := s.conn.Write(append(data, '\n')); err != nil {
+		t.Fatalf("could not write event: %v", err)
+	}
+}
+
+// TestMpvBackend_Listen exercises listen() against a real mpvipc.Connection,
+// so a mismatch with the actual dexterlb/mpvipc API (NewEventListener's
+// channels, Call("observe_property", ...)) fails here instead of only
+// showing up against a live mpv process.
+func TestMpvBackend_Listen(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "mpv.socket")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("could not listen on %s: %v", socketPath, err)
+	}
+	defer ln.Close()
+
+	server := &fakeMpvServer{}
+	accepted := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		server.conn = conn
+		close(accepted)
+		server.acknowledgeCommands(conn)
+	}()
+
+	conn := mpvipc.NewConnection(socketPath)
+	if err := conn.Open(); err != nil {
+		t.Fatalf("could not open connection: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the fake mpv server to accept the connection")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := &MpvBackend{conn: conn, events: make(chan BackendEvent, 1)}
+	go b.listen(ctx)
+
+	// The event listener registers with mpvipc's event hub asynchronously, so
+	// resend the (idempotent) property-change event until it's observed,
+	// instead of racing a single send against that registration.
+	propertyChange := map[string]interface{}{
+		"event": "property-change",
+		"id":    timePosPropertyID,
+		"data":  5.0,
+	}
+	waitForCondition(t, func() bool {
+		server.sendEvent(t, propertyChange)
+		return b.Position() == 5
+	})
+
+	// By now the listener is known to be registered with the same event hub,
+	// so a single end-file event is enough.
+	server.sendEvent(t, map[string]interface{}{
+		"event":  "end-file",
+		"reason": "eof",
+	})
+
+	select {
+	case event := <-b.Events():
+		if event.Type != EventEndOfFile {
+			t.Fatalf("event.Type = %v, want EventEndOfFile", event.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for EventEndOfFile")
+	}
+}
+
+func waitForCondition(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
@@ -0,0 +1,77 @@
+package playback
+
+import "sync"
+
+// NullBackend is a no-op Backend, used in tests and other headless setups
+// where no audio actually needs to play (e.g. a minimal Docker image without
+// mpv or real sound hardware).
+type NullBackend struct {
+	mu      sync.Mutex
+	path    string
+	playing bool
+	pos     int
+	gain    float32
+
+	events chan BackendEvent
+}
+
+func NewNullBackend() *NullBackend {
+	return &NullBackend{events: make(chan BackendEvent, 1)}
+}
+
+func (b *NullBackend) Load(path string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.path = path
+	b.pos = 0
+	return nil
+}
+
+func (b *NullBackend) Play() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.playing = true
+	return nil
+}
+
+func (b *NullBackend) Pause() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.playing = false
+	return nil
+}
+
+func (b *NullBackend) IsPlaying() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.playing
+}
+
+func (b *NullBackend) Seek(offset int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pos = offset
+	return nil
+}
+
+func (b *NullBackend) Volume(gain float32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gain = gain
+	return nil
+}
+
+func (b *NullBackend) Position() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pos
+}
+
+func (b *NullBackend) Events() <-chan BackendEvent {
+	return b.events
+}
+
+func (b *NullBackend) Close() error {
+	close(b.events)
+	return nil
+}
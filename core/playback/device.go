@@ -14,11 +14,18 @@ type PlaybackDevice interface {
 	Start(context.Context) (DeviceStatus, error)
 	Stop(context.Context) (DeviceStatus, error)
 	Skip(context.Context, int, int) (DeviceStatus, error)
+	Previous(context.Context) (DeviceStatus, error)
 	Add(context.Context, []string) (DeviceStatus, error)
 	Clear(context.Context) (DeviceStatus, error)
 	Remove(context.Context, int) (DeviceStatus, error)
 	Shuffle(context.Context) (DeviceStatus, error)
+	Unshuffle(context.Context) (DeviceStatus, error)
 	SetGain(context.Context, float32) (DeviceStatus, error)
+	// SetRepeat is not yet reachable from the Subsonic Jukebox endpoint as an
+	// extension parameter: this tree has no Subsonic/API handler layer to wire
+	// it into (the repo snapshot this series was built against only goes down
+	// to core/playback). Wiring it up is pending that layer landing here.
+	SetRepeat(context.Context, RepeatMode) (DeviceStatus, error)
 }
 
 type DeviceStatus struct {
@@ -26,4 +33,12 @@ type DeviceStatus struct {
 	Playing      bool
 	Gain         float32
 	Position     int
+	Repeat       RepeatMode
+	Shuffled     bool
+}
+
+// PlaybackServer is the dependency a PlaybackDevice uses to resolve the track
+// ids passed in through the Subsonic Jukebox API into actual media files.
+type PlaybackServer interface {
+	GetMediaFile(id string) (*model.MediaFile, error)
 }
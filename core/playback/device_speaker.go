@@ -1,103 +1,65 @@
 package playback
 
-// Audio-playback using mpv media-server. See mpv.io
-// https://github.com/dexterlb/mpvipc
-// https://mpv.io/manual/master/#json-ipc
-// https://mpv.io/manual/master/#properties
+// SpeakerPlaybackDevice implements the Subsonic Jukebox mode commands on top
+// of a pluggable Backend (mpv, a pure-Go decoder/output stack, or a no-op
+// backend for tests). See Backend for the actual audio output contract.
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
-	"time"
 
-	"github.com/dexterlb/mpvipc"
-	"github.com/navidrome/navidrome/core/playback/mpv"
 	"github.com/navidrome/navidrome/log"
 	"github.com/navidrome/navidrome/model"
 )
 
-type SpeakerTrack interface {
-	IsPlaying() bool
-	SetVolume(value float32) // Used to control the playback volume. A float value between 0.0 and 1.0.
-	Pause()
-	Unpause()
-	Position() int
-	SetPosition(offset int) error
-	Close()
-	String() string
-}
-
 type SpeakerPlaybackDevice struct {
 	serviceCtx           context.Context
 	ParentPlaybackServer PlaybackServer
-	MpvConn              *mpvipc.Connection
+	Backend              Backend
 	Default              bool
-	Events               mpvipc.Event
 	Name                 string
 	DeviceName           string
 	PlaybackQueue        *Queue
 	Gain                 float32
-	PlaybackDone         chan bool
+	Repeat               RepeatMode
 	startTrackSwitcher   sync.Once
-}
-
-func (pd *SpeakerPlaybackDevice) Position() int {
-	retryCount := 0
-	for {
-		position, err := pd.MpvConn.Get("time-pos")
-		if err != nil && err.Error() == "mpv error: property unavailable" {
-			retryCount += 1
-			log.Debug("Got mpv error, retrying...", "retries", retryCount, err)
-			if retryCount > 5 {
-				return 0
-			}
-			time.Sleep(time.Duration(retryCount) * time.Millisecond)
-			continue
-		}
-
-		if err != nil {
-			log.Error("Error getting position in track", "track", pd, err)
-			return 0
-		}
 
-		pos, ok := position.(float64)
-		if !ok {
-			log.Error("Could not cast position from mpv into float64", "position", position, "track", pd)
-			return 0
-		} else {
-			return int(pos)
-		}
-	}
+	// mu serializes Backend calls together with the queue mutation they go with,
+	// so that e.g. a Remove racing with an end-file-triggered advance can't leave
+	// the queue's Index pointing past the end.
+	mu sync.Mutex
 }
 
 func (pd *SpeakerPlaybackDevice) getStatus() DeviceStatus {
 	return DeviceStatus{
-		CurrentIndex: pd.PlaybackQueue.Index,
-		Playing:      pd.isPlaying(),
+		CurrentIndex: pd.PlaybackQueue.Index(),
+		Playing:      pd.Backend.IsPlaying(),
 		Gain:         pd.Gain,
-		Position:     pd.Position(),
+		Position:     pd.Backend.Position(),
+		Repeat:       pd.Repeat,
+		Shuffled:     pd.PlaybackQueue.Shuffled(),
 	}
 }
 
-// NewPlaybackDevice creates a new playback device which implements all the basic Jukebox mode commands defined here:
+// NewSpeakerPlaybackDevice creates a new playback device which implements all the basic Jukebox mode commands defined here:
 // http://www.subsonic.org/pages/api.jsp#jukeboxControl
 // Starts the trackSwitcher goroutine for the device.
 func NewSpeakerPlaybackDevice(ctx context.Context, playbackServer PlaybackServer, name string, deviceName string) *SpeakerPlaybackDevice {
-	conn, err := mpv.OpenMpvAndConnection(ctx, deviceName)
-	_ = err
+	backend, err := newBackend(ctx, deviceName)
+	if err != nil {
+		log.Error(ctx, "Error creating playback backend, falling back to a no-op backend", "device", name, err)
+		backend = NewNullBackend()
+	}
 	pd := &SpeakerPlaybackDevice{
 		serviceCtx:           ctx,
 		ParentPlaybackServer: playbackServer,
 		Name:                 name,
-		MpvConn:              conn,
+		Backend:              backend,
 		DeviceName:           deviceName,
 		Gain:                 1.0,
 		PlaybackQueue:        NewQueue(),
-		PlaybackDone:         make(chan bool),
 	}
-	//pd.Events = make(chan mpvipc.Event)
 	return pd
 }
 
@@ -138,14 +100,17 @@ func (pd *SpeakerPlaybackDevice) Start(ctx context.Context) (DeviceStatus, error
 		}()
 	})
 
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
 	if !pd.PlaybackQueue.IsEmpty() {
-		err := pd.switchActiveTrackByIndex(pd.PlaybackQueue.Index, 0)
+		err := pd.loadCurrentTrack()
 		if err != nil {
 			return pd.getStatus(), err
 		}
-		err = pd.MpvConn.Set("pause", false)
+		err = pd.Backend.Play()
 		if err != nil {
-			log.Error("Error pausing track", "track", pd, err)
+			log.Error("Error starting playback", "track", pd, err)
 		}
 	}
 
@@ -155,26 +120,53 @@ func (pd *SpeakerPlaybackDevice) Start(ctx context.Context) (DeviceStatus, error
 func (pd *SpeakerPlaybackDevice) Stop(ctx context.Context) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing Stop action", "device", pd)
 
-	err := pd.MpvConn.Set("pause", true)
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	pd.pause()
+
+	return pd.getStatus(), nil
+}
+
+// pause pauses the Backend. Callers must hold pd.mu.
+func (pd *SpeakerPlaybackDevice) pause() {
+	err := pd.Backend.Pause()
 	if err != nil {
 		log.Error("Error pausing track", "track", pd, err)
 	}
-
-	return pd.getStatus(), nil
 }
 
 func (pd *SpeakerPlaybackDevice) Skip(ctx context.Context, index int, offset int) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing Skip action", "index", index, "offset", offset, "device", pd)
 
-	if index != pd.PlaybackQueue.Index {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
+	if index != pd.PlaybackQueue.Index() {
 		pd.switchActiveTrackByIndex(index, offset)
-	} else {
-		pd.MpvConn.Call("seek", offset)
+	} else if err := pd.Backend.Seek(offset); err != nil {
+		log.Error("Error seeking track", "track", pd, err)
 	}
 
 	return pd.getStatus(), nil
 }
 
+// Previous moves playback back to the track before the current one, if any
+// is available in the queue's history.
+func (pd *SpeakerPlaybackDevice) Previous(ctx context.Context) (DeviceStatus, error) {
+	log.Debug(ctx, "Processing Previous action", "device", pd)
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
+	if !pd.PlaybackQueue.Previous() {
+		return pd.getStatus(), nil
+	}
+	if err := pd.loadCurrentTrack(); err != nil {
+		return pd.getStatus(), err
+	}
+	return pd.getStatus(), nil
+}
+
 func (pd *SpeakerPlaybackDevice) Add(ctx context.Context, ids []string) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing Add action", "ids", ids, "device", pd)
 	if len(ids) < 1 {
@@ -191,6 +183,9 @@ func (pd *SpeakerPlaybackDevice) Add(ctx context.Context, ids []string) (DeviceS
 		log.Debug(ctx, "Found mediafile: "+mf.Path)
 		items = append(items, *mf)
 	}
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
 	pd.PlaybackQueue.Add(items)
 
 	return pd.getStatus(), nil
@@ -198,20 +193,24 @@ func (pd *SpeakerPlaybackDevice) Add(ctx context.Context, ids []string) (DeviceS
 
 func (pd *SpeakerPlaybackDevice) Clear(ctx context.Context) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing Clear action", "device", pd)
-	pd.Stop(ctx)
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	pd.pause()
 	pd.PlaybackQueue.Clear()
+
 	return pd.getStatus(), nil
 }
 
 func (pd *SpeakerPlaybackDevice) Remove(ctx context.Context, index int) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing Remove action", "index", index, "device", pd)
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
 	// pausing if attempting to remove running track
-	if pd.isPlaying() && pd.PlaybackQueue.Index == index {
-		_, err := pd.Stop(ctx)
-		if err != nil {
-			log.Error(ctx, "error stopping running track")
-			return pd.getStatus(), err
-		}
+	if pd.Backend.IsPlaying() && pd.PlaybackQueue.Index() == index {
+		pd.pause()
 	}
 
 	if index > -1 && index < pd.PlaybackQueue.Size() {
@@ -224,19 +223,36 @@ func (pd *SpeakerPlaybackDevice) Remove(ctx context.Context, index int) (DeviceS
 
 func (pd *SpeakerPlaybackDevice) Shuffle(ctx context.Context) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing Shuffle action", "device", pd)
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
 	if pd.PlaybackQueue.Size() > 1 {
 		pd.PlaybackQueue.Shuffle()
 	}
 	return pd.getStatus(), nil
 }
 
+// Unshuffle restores the queue to the order it had before Shuffle was
+// called, keeping the currently playing track selected.
+func (pd *SpeakerPlaybackDevice) Unshuffle(ctx context.Context) (DeviceStatus, error) {
+	log.Debug(ctx, "Processing Unshuffle action", "device", pd)
+
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
+	pd.PlaybackQueue.Unshuffle()
+	return pd.getStatus(), nil
+}
+
 // SetGain is used to control the playback volume. A float value between 0.0 and 1.0.
 func (pd *SpeakerPlaybackDevice) SetGain(ctx context.Context, gain float32) (DeviceStatus, error) {
 	log.Debug(ctx, "Processing SetGain action", "newGain", gain, "device", pd)
 
-	vol := int(gain * 100)
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
 
-	err := pd.MpvConn.Set("volume", vol)
+	err := pd.Backend.Volume(gain)
 	if err != nil {
 		log.Error("Error setting volume", "volume", gain, "track", pd, err)
 	}
@@ -245,45 +261,37 @@ func (pd *SpeakerPlaybackDevice) SetGain(ctx context.Context, gain float32) (Dev
 	return pd.getStatus(), nil
 }
 
-func (pd *SpeakerPlaybackDevice) isPlaying() bool {
-	pausing, err := pd.MpvConn.Get("pause")
-	if err != nil {
-		log.Error("Problem getting paused status", "track", pd, err)
-		return false
-	}
+// SetRepeat changes how the queue behaves when a track ends: RepeatTrack
+// reloads the current track; otherwise the queue advances, and after the
+// last track playback stops (RepeatOff) or wraps back to the start of the
+// queue (RepeatQueue).
+func (pd *SpeakerPlaybackDevice) SetRepeat(ctx context.Context, mode RepeatMode) (DeviceStatus, error) {
+	log.Debug(ctx, "Processing SetRepeat action", "mode", mode, "device", pd)
 
-	pause, ok := pausing.(bool)
-	if !ok {
-		log.Error("Could not cast pausing to boolean", "track", pd, "value", pausing)
-		return false
-	}
-	return !pause
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	pd.Repeat = mode
+
+	return pd.getStatus(), nil
 }
 
+// trackSwitcherGoroutine drains the Backend's event channel and drives
+// auto-advance of the playback queue. It exits as soon as pd.serviceCtx is
+// done.
 func (pd *SpeakerPlaybackDevice) trackSwitcherGoroutine() {
 	log.Debug("Started trackSwitcher goroutine", "device", pd)
+	defer pd.closeBackend()
+
 	for {
 		select {
-		case <-pd.PlaybackDone:
-			//log.Debug("Track switching detected")
-			//if pd.ActiveTrack != nil {
-			//	pd.ActiveTrack.Close()
-			//	pd.ActiveTrack = nil
-			//}
-			//
-			//if !pd.PlaybackQueue.IsAtLastElement() {
-			//	pd.PlaybackQueue.IncreaseIndex()
-			//	log.Debug("Switching to next song", "queue", pd.PlaybackQueue.String())
-			//	err := pd.switchActiveTrackByIndex(pd.PlaybackQueue.Index, 0)
-			//	if err != nil {
-			//		log.Error("Error switching track", err)
-			//	}
-			//	if pd.ActiveTrack != nil {
-			//		pd.ActiveTrack.Unpause()
-			//	}
-			//} else {
-			//	log.Debug("There is no song left in the playlist. Finish.")
-			//}
+		case event, ok := <-pd.Backend.Events():
+			if !ok {
+				log.Debug("Backend event channel closed, stopping trackSwitcher goroutine", "device", pd.Name)
+				return
+			}
+			if event.Type == EventEndOfFile {
+				pd.advanceQueue()
+			}
 		case <-pd.serviceCtx.Done():
 			log.Debug("Stopping trackSwitcher goroutine", "device", pd.Name)
 			return
@@ -291,14 +299,59 @@ func (pd *SpeakerPlaybackDevice) trackSwitcherGoroutine() {
 	}
 }
 
+// closeBackend releases the Backend's resources (the mpv control socket, or
+// the speaker's audio device) once the trackSwitcher goroutine is done with
+// it.
+func (pd *SpeakerPlaybackDevice) closeBackend() {
+	if err := pd.Backend.Close(); err != nil {
+		log.Error("Error closing playback backend", "device", pd, err)
+	}
+}
+
+// advanceQueue moves the queue to the next track after the Backend reports
+// the current one reached end-of-file, and starts playing it, honoring the
+// device's Repeat mode.
+func (pd *SpeakerPlaybackDevice) advanceQueue() {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+
+	if pd.Repeat == RepeatTrack {
+		log.Debug("Repeating current track", "queue", pd.PlaybackQueue.String())
+		if err := pd.loadCurrentTrack(); err != nil {
+			log.Error("Error repeating track", "device", pd, err)
+		}
+		return
+	}
+
+	if !pd.PlaybackQueue.Advance() {
+		if pd.Repeat != RepeatQueue || !pd.PlaybackQueue.Rewind() {
+			log.Debug("There is no song left in the playlist. Finish.", "device", pd)
+			pd.pause()
+			return
+		}
+		log.Debug("Repeating queue from the start", "queue", pd.PlaybackQueue.String())
+	}
+
+	log.Debug("Switching to next song", "queue", pd.PlaybackQueue.String())
+	if err := pd.loadCurrentTrack(); err != nil {
+		log.Error("Error switching track", "device", pd, err)
+	}
+}
+
+// switchActiveTrackByIndex jumps the queue to the track at the given
+// absolute index and loads it into the Backend. Callers must hold pd.mu.
 func (pd *SpeakerPlaybackDevice) switchActiveTrackByIndex(index int, offset int) error {
 	pd.PlaybackQueue.SetIndex(index)
+	return pd.loadCurrentTrack()
+}
+
+// loadCurrentTrack tells the Backend to play the queue's current track.
+// Callers must hold pd.mu.
+func (pd *SpeakerPlaybackDevice) loadCurrentTrack() error {
 	currentTrack := pd.PlaybackQueue.Current()
 	if currentTrack == nil {
-		return errors.New("could not get current track")
+		return fmt.Errorf("could not get current track")
 	}
 
-	pd.MpvConn.Call("loadfile", currentTrack.Path, "replace", 0, "start=10")
-
-	return nil
+	return pd.Backend.Load(currentTrack.Path)
 }
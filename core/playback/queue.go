@@ -0,0 +1,341 @@
+package playback
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/navidrome/navidrome/model"
+)
+
+// Queue represents the current Jukebox playback queue, split into three
+// segments: Done (already played, oldest first), Playing (the current
+// track, nil if nothing is playing) and Ahead (upcoming tracks, in play
+// order). Its methods take the queue's lock, as the queue is read and
+// mutated concurrently by Subsonic API handlers and by the trackSwitcher
+// goroutine; the exported segment fields themselves are not guarded.
+type Queue struct {
+	mu sync.RWMutex
+
+	Done    model.MediaFiles
+	Playing *model.MediaFile
+	Ahead   model.MediaFiles
+
+	// preShuffleAhead holds Ahead's order before Shuffle was called, so
+	// Unshuffle can restore it. It is nil when Ahead isn't shuffled.
+	preShuffleAhead model.MediaFiles
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		Done:  model.MediaFiles{},
+		Ahead: model.MediaFiles{},
+	}
+}
+
+func (pq *Queue) String() string {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	all := pq.allLocked()
+	playingIdx := len(pq.Done)
+
+	str := "["
+	for idx, item := range all {
+		if pq.Playing != nil && idx == playingIdx {
+			str += "*"
+		}
+		str += item.Title
+		if idx != len(all)-1 {
+			str += ", "
+		}
+	}
+	str += "]"
+	return str
+}
+
+// Add appends the given tracks to the end of the upcoming (Ahead) segment.
+func (pq *Queue) Add(mediaFiles model.MediaFiles) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.Ahead = append(pq.Ahead, mediaFiles...)
+	if pq.preShuffleAhead != nil {
+		pq.preShuffleAhead = append(pq.preShuffleAhead, mediaFiles...)
+	}
+}
+
+// Set replaces the whole queue: the first track becomes the one Playing, the
+// rest become Ahead, and any history or shuffle view is discarded.
+func (pq *Queue) Set(mediaFiles model.MediaFiles) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	pq.Done = model.MediaFiles{}
+	pq.preShuffleAhead = nil
+
+	if len(mediaFiles) == 0 {
+		pq.Playing = nil
+		pq.Ahead = model.MediaFiles{}
+		return
+	}
+	playing := mediaFiles[0]
+	pq.Playing = &playing
+	pq.Ahead = append(model.MediaFiles{}, mediaFiles[1:]...)
+}
+
+func (pq *Queue) Clear() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.Done = model.MediaFiles{}
+	pq.Playing = nil
+	pq.Ahead = model.MediaFiles{}
+	pq.preShuffleAhead = nil
+}
+
+// Remove removes the track at the given absolute index, counting Done,
+// Playing and Ahead as a single ordered list.
+func (pq *Queue) Remove(idx int) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	all := pq.allLocked()
+	if idx < 0 || idx >= len(all) {
+		return
+	}
+
+	playingIdx := -1
+	if pq.Playing != nil {
+		playingIdx = len(pq.Done)
+	}
+
+	all = append(all[:idx], all[idx+1:]...)
+
+	if playingIdx >= 0 && idx < playingIdx {
+		playingIdx--
+	}
+	if playingIdx >= len(all) {
+		playingIdx = len(all) - 1
+	}
+	pq.rebuildFromAllLocked(all, playingIdx)
+}
+
+func (pq *Queue) Size() int {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+	n := len(pq.Done) + len(pq.Ahead)
+	if pq.Playing != nil {
+		n++
+	}
+	return n
+}
+
+func (pq *Queue) IsEmpty() bool {
+	return pq.Size() == 0
+}
+
+func (pq *Queue) Current() *model.MediaFile {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+	if pq.Playing == nil {
+		return nil
+	}
+	item := *pq.Playing
+	return &item
+}
+
+// Index returns the absolute index of the currently playing track, counting
+// Done, Playing and Ahead as a single ordered list, or -1 if nothing is
+// playing.
+func (pq *Queue) Index() int {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+	if pq.Playing == nil {
+		return -1
+	}
+	return len(pq.Done)
+}
+
+// SetIndex jumps to the track at the given absolute index, moving everything
+// before it into Done and everything after it into Ahead.
+func (pq *Queue) SetIndex(idx int) {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.rebuildFromAllLocked(pq.allLocked(), idx)
+}
+
+// Get returns the full queue - history, current track and upcoming tracks -
+// as a single ordered list, so Subsonic clients can render recently-played
+// context alongside what's still to come.
+func (pq *Queue) Get() model.MediaFiles {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+	return pq.allLocked()
+}
+
+// At returns the track relativeIndex positions away from the one currently
+// playing: 0 is the current track, negative indexes reach back into history
+// (-1 is the most recently played track), positive indexes reach forward
+// into the upcoming tracks (1 is the next one). It returns nil if there is
+// no track at that position.
+func (pq *Queue) At(relativeIndex int) *model.MediaFile {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	switch {
+	case relativeIndex == 0:
+		if pq.Playing == nil {
+			return nil
+		}
+		item := *pq.Playing
+		return &item
+	case relativeIndex < 0:
+		idx := len(pq.Done) + relativeIndex
+		if idx < 0 || idx >= len(pq.Done) {
+			return nil
+		}
+		item := pq.Done[idx]
+		return &item
+	default:
+		idx := relativeIndex - 1
+		if idx < 0 || idx >= len(pq.Ahead) {
+			return nil
+		}
+		item := pq.Ahead[idx]
+		return &item
+	}
+}
+
+// InBounds reports whether there is a track at relativeIndex. See At.
+func (pq *Queue) InBounds(relativeIndex int) bool {
+	return pq.At(relativeIndex) != nil
+}
+
+// Advance moves the currently playing track into Done and starts playing the
+// next track popped from Ahead. It returns false, leaving the queue
+// unchanged, if Ahead is empty.
+func (pq *Queue) Advance() bool {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	if len(pq.Ahead) == 0 {
+		return false
+	}
+	if pq.Playing != nil {
+		pq.Done = append(pq.Done, *pq.Playing)
+	}
+	next := pq.Ahead[0]
+	pq.Playing = &next
+	pq.Ahead = append(model.MediaFiles{}, pq.Ahead[1:]...)
+	pq.preShuffleAhead = nil
+	return true
+}
+
+// Previous pops the currently playing track back to the front of Ahead and
+// resumes playing the last track from Done. It returns false, leaving the
+// queue unchanged, if Done is empty.
+func (pq *Queue) Previous() bool {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	if len(pq.Done) == 0 {
+		return false
+	}
+	last := pq.Done[len(pq.Done)-1]
+	pq.Done = pq.Done[:len(pq.Done)-1]
+	if pq.Playing != nil {
+		pq.Ahead = append(model.MediaFiles{*pq.Playing}, pq.Ahead...)
+	}
+	pq.Playing = &last
+	pq.preShuffleAhead = nil
+	return true
+}
+
+// Rewind starts the queue over from the beginning: the first track becomes
+// the one Playing and every other track, history included, moves into Ahead.
+// Used to implement RepeatQueue. It returns false, leaving the queue
+// unchanged, if the queue is empty.
+func (pq *Queue) Rewind() bool {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	all := pq.allLocked()
+	if len(all) == 0 {
+		return false
+	}
+	pq.rebuildFromAllLocked(all, 0)
+	return true
+}
+
+// Shuffle randomizes the order of the upcoming (Ahead) tracks, using the
+// Fisher-Yates algorithm. History and the currently playing track are left
+// untouched. The pre-shuffle order is kept so Unshuffle can restore it.
+func (pq *Queue) Shuffle() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	if pq.preShuffleAhead != nil {
+		return
+	}
+
+	pq.preShuffleAhead = make(model.MediaFiles, len(pq.Ahead))
+	copy(pq.preShuffleAhead, pq.Ahead)
+
+	rand.Shuffle(len(pq.Ahead), func(i, j int) {
+		pq.Ahead[i], pq.Ahead[j] = pq.Ahead[j], pq.Ahead[i]
+	})
+}
+
+// Unshuffle restores the order Ahead had before Shuffle was called. It is a
+// no-op if Ahead isn't currently shuffled.
+func (pq *Queue) Unshuffle() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	if pq.preShuffleAhead == nil {
+		return
+	}
+	pq.Ahead = pq.preShuffleAhead
+	pq.preShuffleAhead = nil
+}
+
+// Shuffled reports whether Ahead is currently showing a shuffled view.
+func (pq *Queue) Shuffled() bool {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+	return pq.preShuffleAhead != nil
+}
+
+// allLocked returns Done, Playing and Ahead concatenated into a single
+// ordered list. Callers must hold pq.mu.
+func (pq *Queue) allLocked() model.MediaFiles {
+	all := make(model.MediaFiles, 0, len(pq.Done)+len(pq.Ahead)+1)
+	all = append(all, pq.Done...)
+	if pq.Playing != nil {
+		all = append(all, *pq.Playing)
+	}
+	all = append(all, pq.Ahead...)
+	return all
+}
+
+// rebuildFromAllLocked re-splits all into Done/Playing/Ahead around
+// playingIdx, clearing any shuffle view - the tracks now ahead may no longer
+// match what Unshuffle had recorded. Callers must hold pq.mu.
+func (pq *Queue) rebuildFromAllLocked(all model.MediaFiles, playingIdx int) {
+	pq.preShuffleAhead = nil
+
+	if playingIdx < 0 || playingIdx >= len(all) {
+		pq.Done = append(model.MediaFiles{}, all...)
+		pq.Playing = nil
+		pq.Ahead = model.MediaFiles{}
+		return
+	}
+
+	done := make(model.MediaFiles, playingIdx)
+	copy(done, all[:playingIdx])
+	playing := all[playingIdx]
+	ahead := make(model.MediaFiles, len(all)-playingIdx-1)
+	copy(ahead, all[playingIdx+1:])
+
+	pq.Done = done
+	pq.Playing = &playing
+	pq.Ahead = ahead
+}
@@ -0,0 +1,263 @@
+package playback
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/navidrome/navidrome/model"
+)
+
+// newTestMediaFiles builds a model.MediaFiles with one track per id, in order.
+func newTestMediaFiles(ids ...string) model.MediaFiles {
+	mfs := make(model.MediaFiles, len(ids))
+	for i, id := range ids {
+		mfs[i] = model.MediaFile{ID: id, Title: "track " + id}
+	}
+	return mfs
+}
+
+func idsOf(mfs model.MediaFiles) []string {
+	ids := make([]string, len(mfs))
+	for i, mf := range mfs {
+		ids[i] = mf.ID
+	}
+	return ids
+}
+
+func TestQueue_ShuffleUnshuffle(t *testing.T) {
+	pq := NewQueue()
+	pq.Set(newTestMediaFiles("1", "2", "3", "4", "5"))
+
+	if pq.Shuffled() {
+		t.Fatal("Shuffled() = true before Shuffle was called")
+	}
+
+	originalAhead := append(model.MediaFiles{}, pq.Ahead...)
+	pq.Shuffle()
+
+	if !pq.Shuffled() {
+		t.Fatal("Shuffled() = false after Shuffle")
+	}
+	if pq.Current() == nil || pq.Current().ID != "1" {
+		t.Fatalf("Shuffle changed the currently playing track: %v", pq.Current())
+	}
+	if len(pq.Ahead) != len(originalAhead) {
+		t.Fatalf("Shuffle changed Ahead's length: got %d, want %d", len(pq.Ahead), len(originalAhead))
+	}
+	if !sameElements(idsOf(pq.Ahead), idsOf(originalAhead)) {
+		t.Fatalf("Shuffle changed Ahead's elements: got %v, want a permutation of %v", idsOf(pq.Ahead), idsOf(originalAhead))
+	}
+
+	// A second Shuffle while already shuffled must be a no-op, so the
+	// original pre-shuffle order stays recoverable.
+	shuffledOnce := append(model.MediaFiles{}, pq.Ahead...)
+	pq.Shuffle()
+	if !reflect.DeepEqual(pq.Ahead, shuffledOnce) {
+		t.Fatalf("Shuffle while already shuffled changed Ahead: got %v, want %v", idsOf(pq.Ahead), idsOf(shuffledOnce))
+	}
+
+	pq.Unshuffle()
+	if pq.Shuffled() {
+		t.Fatal("Shuffled() = true after Unshuffle")
+	}
+	if !reflect.DeepEqual(pq.Ahead, originalAhead) {
+		t.Fatalf("Unshuffle did not restore the original order: got %v, want %v", idsOf(pq.Ahead), idsOf(originalAhead))
+	}
+
+	// Unshuffle when not shuffled is a no-op.
+	pq.Unshuffle()
+	if !reflect.DeepEqual(pq.Ahead, originalAhead) {
+		t.Fatalf("Unshuffle with no shuffle view changed Ahead: got %v, want %v", idsOf(pq.Ahead), idsOf(originalAhead))
+	}
+}
+
+func TestQueue_ShuffleClearedByMutation(t *testing.T) {
+	pq := NewQueue()
+	pq.Set(newTestMediaFiles("1", "2", "3"))
+	pq.Shuffle()
+
+	if !pq.Shuffled() {
+		t.Fatal("Shuffled() = false after Shuffle")
+	}
+
+	pq.Advance()
+	if pq.Shuffled() {
+		t.Fatal("Shuffled() = true after Advance, want the shuffle view cleared")
+	}
+}
+
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		counts[v]--
+	}
+	for _, c := range counts {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQueue_AdvancePreviousRewind(t *testing.T) {
+	pq := NewQueue()
+	pq.Set(newTestMediaFiles("1", "2", "3"))
+
+	if !pq.Advance() {
+		t.Fatal("Advance() = false, want true")
+	}
+	if got := pq.Current().ID; got != "2" {
+		t.Fatalf("Current() after Advance = %s, want 2", got)
+	}
+	if got := idsOf(pq.Done); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Fatalf("Done after Advance = %v, want [1]", got)
+	}
+
+	if !pq.Advance() {
+		t.Fatal("Advance() = false, want true")
+	}
+	if got := pq.Current().ID; got != "3" {
+		t.Fatalf("Current() after second Advance = %s, want 3", got)
+	}
+	if pq.Advance() {
+		t.Fatal("Advance() = true at the end of the queue, want false")
+	}
+
+	if !pq.Previous() {
+		t.Fatal("Previous() = false, want true")
+	}
+	if got := pq.Current().ID; got != "2" {
+		t.Fatalf("Current() after Previous = %s, want 2", got)
+	}
+	if got := idsOf(pq.Ahead); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Fatalf("Ahead after Previous = %v, want [3]", got)
+	}
+
+	if !pq.Previous() {
+		t.Fatal("Previous() = false, want true")
+	}
+	if pq.Previous() {
+		t.Fatal("Previous() = true with an empty history, want false")
+	}
+
+	if !pq.Rewind() {
+		t.Fatal("Rewind() = false, want true")
+	}
+	if got := pq.Current().ID; got != "1" {
+		t.Fatalf("Current() after Rewind = %s, want 1", got)
+	}
+	if got := idsOf(pq.Get()); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Fatalf("Get() after Rewind = %v, want [1 2 3]", got)
+	}
+
+	pq.Clear()
+	if pq.Rewind() {
+		t.Fatal("Rewind() = true on an empty queue, want false")
+	}
+}
+
+func TestQueue_AtAndInBounds(t *testing.T) {
+	pq := NewQueue()
+	pq.Set(newTestMediaFiles("1", "2", "3"))
+	pq.Advance() // Done: [1], Playing: 2, Ahead: [3]
+
+	cases := []struct {
+		relIdx  int
+		wantID  string
+		inBound bool
+	}{
+		{relIdx: -2, wantID: "", inBound: false},
+		{relIdx: -1, wantID: "1", inBound: true},
+		{relIdx: 0, wantID: "2", inBound: true},
+		{relIdx: 1, wantID: "3", inBound: true},
+		{relIdx: 2, wantID: "", inBound: false},
+	}
+	for _, c := range cases {
+		got := pq.At(c.relIdx)
+		if c.inBound != pq.InBounds(c.relIdx) {
+			t.Errorf("InBounds(%d) = %v, want %v", c.relIdx, pq.InBounds(c.relIdx), c.inBound)
+		}
+		if !c.inBound {
+			if got != nil {
+				t.Errorf("At(%d) = %v, want nil", c.relIdx, got)
+			}
+			continue
+		}
+		if got == nil || got.ID != c.wantID {
+			t.Errorf("At(%d) = %v, want %s", c.relIdx, got, c.wantID)
+		}
+	}
+}
+
+func TestQueue_RemoveReindexesAroundPlaying(t *testing.T) {
+	pq := NewQueue()
+	pq.Set(newTestMediaFiles("1", "2", "3", "4"))
+	pq.Advance() // Done: [1], Playing: 2, Ahead: [3, 4]
+
+	// Removing an item from history shifts the playing index down, but
+	// Playing itself must not change.
+	pq.Remove(0)
+	if got := pq.Current().ID; got != "2" {
+		t.Fatalf("Current() after removing a history item = %s, want 2", got)
+	}
+	if got := idsOf(pq.Get()); !reflect.DeepEqual(got, []string{"2", "3", "4"}) {
+		t.Fatalf("Get() after removing a history item = %v, want [2 3 4]", got)
+	}
+
+	// Removing the currently playing track falls back to the next track in
+	// Ahead becoming current.
+	pq.Remove(0)
+	if got := pq.Current().ID; got != "3" {
+		t.Fatalf("Current() after removing the playing item = %s, want 3", got)
+	}
+
+	// Removing the last Ahead item leaves the playing track current and
+	// Ahead empty.
+	pq.Remove(1)
+	if got := pq.Current().ID; got != "3" {
+		t.Fatalf("Current() after removing the last Ahead item = %s, want 3", got)
+	}
+	if len(pq.Ahead) != 0 {
+		t.Fatalf("Ahead after removing the last item = %v, want empty", idsOf(pq.Ahead))
+	}
+
+	// Out-of-range indexes are ignored.
+	before := idsOf(pq.Get())
+	pq.Remove(-1)
+	pq.Remove(100)
+	if got := idsOf(pq.Get()); !reflect.DeepEqual(got, before) {
+		t.Fatalf("Remove with an out-of-range index changed the queue: got %v, want %v", got, before)
+	}
+}
+
+func TestQueue_SetIndex(t *testing.T) {
+	pq := NewQueue()
+	pq.Set(newTestMediaFiles("1", "2", "3", "4"))
+
+	pq.SetIndex(2)
+	if got := pq.Current().ID; got != "3" {
+		t.Fatalf("Current() after SetIndex(2) = %s, want 3", got)
+	}
+	if got := idsOf(pq.Done); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Fatalf("Done after SetIndex(2) = %v, want [1 2]", got)
+	}
+	if got := idsOf(pq.Ahead); !reflect.DeepEqual(got, []string{"4"}) {
+		t.Fatalf("Ahead after SetIndex(2) = %v, want [4]", got)
+	}
+
+	// An out-of-bounds index falls back to treating the whole queue as
+	// history, with nothing playing.
+	pq.SetIndex(100)
+	if pq.Current() != nil {
+		t.Fatalf("Current() after SetIndex(100) = %v, want nil", pq.Current())
+	}
+	if got := idsOf(pq.Done); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Fatalf("Done after SetIndex(100) = %v, want [1 2 3 4]", got)
+	}
+}
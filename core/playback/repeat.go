@@ -0,0 +1,25 @@
+package playback
+
+// RepeatMode controls what a PlaybackDevice does with its queue once the
+// currently playing track reaches end-of-file.
+type RepeatMode int
+
+const (
+	// RepeatOff stops playback once the last track in the queue finishes.
+	RepeatOff RepeatMode = iota
+	// RepeatTrack reloads the current track instead of advancing the queue.
+	RepeatTrack
+	// RepeatQueue wraps back around to the first track once the last one finishes.
+	RepeatQueue
+)
+
+func (m RepeatMode) String() string {
+	switch m {
+	case RepeatTrack:
+		return "track"
+	case RepeatQueue:
+		return "queue"
+	default:
+		return "off"
+	}
+}